@@ -0,0 +1,182 @@
+// Copyright (C) 2013-2018, The MetaCurrency Project (Eric Harris-Braun, Arthur Brock, et. al.)
+// Use of this source code is governed by GPLv3 found in the LICENSE file
+//----------------------------------------------------------------------------------------
+// BootstrapEntry and the locality-ordered resolver let a node be configured with more than
+// one bootstrap server, ordered from nearest to farthest. DialBootstrapServers fails over
+// along that order with a caller-supplied dialer; makeConfig does not dial, it keeps only the
+// nearest address.
+
+package holochain
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultBootstrapDialTimeout is a per-entry timeout for use with DialBootstrapServers. No
+// caller in this package passes it; makeConfig resolves the bootstrap server without dialing.
+const DefaultBootstrapDialTimeout = 5 * time.Second
+
+// BootstrapEntry describes one candidate bootstrap server and the locality it was
+// registered under.
+type BootstrapEntry struct {
+	Addr   string
+	Region string
+	Zone   string
+}
+
+// String renders a BootstrapEntry back into the addr[@region[:zone]] form accepted by
+// ParseBootstrapServers.
+func (e BootstrapEntry) String() string {
+	if e.Region == "" && e.Zone == "" {
+		return e.Addr
+	}
+	if e.Zone == "" {
+		return fmt.Sprintf("%s@%s", e.Addr, e.Region)
+	}
+	return fmt.Sprintf("%s@%s:%s", e.Addr, e.Region, e.Zone)
+}
+
+// ParseBootstrapServers parses a comma-separated HOLOCHAINCONFIG_BOOTSTRAP value into a
+// list of BootstrapEntry. Each entry is either a bare "host:port" (backward compatible with
+// the single-value form) or "host:port@region:zone".
+func ParseBootstrapServers(value string) (entries []BootstrapEntry) {
+	if value == "" {
+		return
+	}
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		addr := raw
+		var region, zone string
+		if i := strings.Index(raw, "@"); i >= 0 {
+			addr = raw[:i]
+			locality := raw[i+1:]
+			parts := strings.SplitN(locality, ":", 2)
+			region = parts[0]
+			if len(parts) == 2 {
+				zone = parts[1]
+			}
+		}
+		entries = append(entries, BootstrapEntry{Addr: addr, Region: region, Zone: zone})
+	}
+	return
+}
+
+// ParseLocality parses a HOLOCHAINCONFIG_LOCALITY value of the form "region:zone".
+func ParseLocality(value string) (region, zone string) {
+	parts := strings.SplitN(value, ":", 2)
+	region = parts[0]
+	if len(parts) == 2 {
+		zone = parts[1]
+	}
+	return
+}
+
+// OrderByLocality sorts entries so that same-zone servers come before same-region servers,
+// which come before everything else, preserving relative order within each tier.
+func OrderByLocality(entries []BootstrapEntry, localRegion, localZone string) []BootstrapEntry {
+	ordered := make([]BootstrapEntry, 0, len(entries))
+	var sameZone, sameRegion, remote []BootstrapEntry
+	for _, e := range entries {
+		switch {
+		case localZone != "" && e.Zone == localZone && e.Region == localRegion:
+			sameZone = append(sameZone, e)
+		case localRegion != "" && e.Region == localRegion:
+			sameRegion = append(sameRegion, e)
+		default:
+			remote = append(remote, e)
+		}
+	}
+	ordered = append(ordered, sameZone...)
+	ordered = append(ordered, sameRegion...)
+	ordered = append(ordered, remote...)
+	return ordered
+}
+
+// bootstrapDialer is satisfied by the real bootstrap client and by test fakes.
+type bootstrapDialer func(addr string, timeout time.Duration) error
+
+// DialBootstrapServers tries each entry of ordered in sequence, giving up on an entry after
+// timeout and moving to the next, returning the address of the first one that succeeds.
+func DialBootstrapServers(ordered []BootstrapEntry, timeout time.Duration, dial bootstrapDialer) (addr string, err error) {
+	if len(ordered) == 0 {
+		err = fmt.Errorf("holochain: no bootstrap servers configured")
+		return
+	}
+	var lastErr error
+	for _, e := range ordered {
+		if dialErr := dial(e.Addr, timeout); dialErr != nil {
+			lastErr = dialErr
+			continue
+		}
+		return e.Addr, nil
+	}
+	err = fmt.Errorf("holochain: all bootstrap servers failed, last error: %v", lastErr)
+	return
+}
+
+// resolveBootstrapServers reads HOLOCHAINCONFIG_BOOTSTRAP (a comma-separated list, or the
+// sentinel "_" for no bootstrap servers) and HOLOCHAINCONFIG_LOCALITY ("region:zone"),
+// falling back to s.Settings.BootstrapServers/LocalRegion/LocalZone, and returns the
+// resulting entries ordered from nearest to farthest.
+func resolveBootstrapServers(s *Service) (ordered []BootstrapEntry) {
+	entries := s.Settings.BootstrapServers
+	if v, ok := os.LookupEnv("HOLOCHAINCONFIG_BOOTSTRAP"); ok {
+		if v == "_" {
+			entries = nil
+		} else {
+			entries = ParseBootstrapServers(v)
+		}
+	}
+
+	region, zone := s.Settings.LocalRegion, s.Settings.LocalZone
+	if loc := os.Getenv("HOLOCHAINCONFIG_LOCALITY"); loc != "" {
+		region, zone = ParseLocality(loc)
+	}
+
+	return OrderByLocality(entries, region, zone)
+}
+
+// makeConfig builds h.config from the Service's defaults and OS environment overrides,
+// including resolving the (possibly multi-server) bootstrap configuration down to the single
+// nearest address that HolochainConfig.BootstrapServer still carries, for backward
+// compatibility with everything downstream that dials a single bootstrap server.
+func makeConfig(h *Holochain, s *Service) (err error) {
+	h.config.Port = DefaultPort
+	if p := os.Getenv("HOLOCHAINCONFIG_PORT"); p != "" {
+		if h.config.Port, err = parsePort(p); err != nil {
+			return
+		}
+	}
+
+	h.config.PeerModeDHTNode = s.Settings.DefaultPeerModeDHTNode
+	h.config.PeerModeAuthor = s.Settings.DefaultPeerModeAuthor
+	if v := os.Getenv("HOLOCHAINCONFIG_ENABLEMDNS"); v != "" {
+		h.config.EnableMDNS = v == "true"
+	} else {
+		h.config.EnableMDNS = s.Settings.DefaultEnableMDNS
+	}
+
+	ordered := resolveBootstrapServers(s)
+	if len(ordered) > 0 {
+		h.config.BootstrapServer = ordered[0].Addr
+	} else {
+		h.config.BootstrapServer = ""
+	}
+
+	h.config.Loggers.App.Format = "%{color:cyan}%{message}"
+	if prefix := os.Getenv("HOLOCHAINCONFIG_LOGPREFIX"); prefix != "" {
+		h.config.Loggers.App.Prefix = prefix
+	}
+	return
+}
+
+func parsePort(p string) (port int, err error) {
+	_, err = fmt.Sscanf(p, "%d", &port)
+	return
+}
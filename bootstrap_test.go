@@ -0,0 +1,107 @@
+package holochain
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseBootstrapServers(t *testing.T) {
+	Convey("a single host:port should parse as one entry with no locality", t, func() {
+		entries := ParseBootstrapServers("bootstrap.holochain.net:10000")
+		So(len(entries), ShouldEqual, 1)
+		So(entries[0], ShouldResemble, BootstrapEntry{Addr: "bootstrap.holochain.net:10000"})
+	})
+
+	Convey("a comma-separated list with locality hints should parse in order", t, func() {
+		entries := ParseBootstrapServers("a:1@us:east1,b:2@us:west1,c:3")
+		So(len(entries), ShouldEqual, 3)
+		So(entries[0], ShouldResemble, BootstrapEntry{Addr: "a:1", Region: "us", Zone: "east1"})
+		So(entries[1], ShouldResemble, BootstrapEntry{Addr: "b:2", Region: "us", Zone: "west1"})
+		So(entries[2], ShouldResemble, BootstrapEntry{Addr: "c:3"})
+	})
+}
+
+func TestParseLocality(t *testing.T) {
+	Convey("it should split region:zone", t, func() {
+		region, zone := ParseLocality("us:east1")
+		So(region, ShouldEqual, "us")
+		So(zone, ShouldEqual, "east1")
+	})
+}
+
+func TestOrderByLocality(t *testing.T) {
+	entries := ParseBootstrapServers("remote:1@eu:west1,zone:2@us:east1,region:3@us:west1")
+
+	Convey("same-zone entries should sort before same-region, before remote", t, func() {
+		ordered := OrderByLocality(entries, "us", "east1")
+		So(ordered[0].Addr, ShouldEqual, "zone:2")
+		So(ordered[1].Addr, ShouldEqual, "region:3")
+		So(ordered[2].Addr, ShouldEqual, "remote:1")
+	})
+}
+
+func TestDialBootstrapServers(t *testing.T) {
+	entries := ParseBootstrapServers("bad:1,good:2")
+
+	Convey("it should skip a failing entry and succeed on the next", t, func() {
+		addr, err := DialBootstrapServers(entries, time.Second, func(a string, timeout time.Duration) error {
+			if a == "bad:1" {
+				return fmt.Errorf("connection refused")
+			}
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(addr, ShouldEqual, "good:2")
+	})
+
+	Convey("it should return an error when every entry fails", t, func() {
+		_, err := DialBootstrapServers(entries, time.Second, func(a string, timeout time.Duration) error {
+			return fmt.Errorf("connection refused")
+		})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestMakeConfigBootstrapLocality(t *testing.T) {
+	d, s := setupTestService()
+	defer CleanupTestDir(d)
+	h := &Holochain{encodingFormat: "json", rootPath: d}
+
+	s.Settings.BootstrapServers = ParseBootstrapServers("remote:1@eu:west1,zone:2@us:east1,region:3@us:west1")
+	s.Settings.LocalRegion = "us"
+	s.Settings.LocalZone = "east1"
+
+	Convey("with no env override, it should resolve BootstrapServer to the nearest configured entry", t, func() {
+		err := makeConfig(h, s)
+		So(err, ShouldBeNil)
+		So(h.config.BootstrapServer, ShouldEqual, "zone:2")
+	})
+
+	Convey("HOLOCHAINCONFIG_BOOTSTRAP should override the list as a comma-separated value", t, func() {
+		os.Setenv("HOLOCHAINCONFIG_BOOTSTRAP", "a:1@us:east1,b:2")
+		defer os.Unsetenv("HOLOCHAINCONFIG_BOOTSTRAP")
+		err := makeConfig(h, s)
+		So(err, ShouldBeNil)
+		So(h.config.BootstrapServer, ShouldEqual, "a:1")
+	})
+
+	Convey("HOLOCHAINCONFIG_LOCALITY should override the node's own region:zone for ordering", t, func() {
+		os.Setenv("HOLOCHAINCONFIG_LOCALITY", "us:west1")
+		defer os.Unsetenv("HOLOCHAINCONFIG_LOCALITY")
+		err := makeConfig(h, s)
+		So(err, ShouldBeNil)
+		So(h.config.BootstrapServer, ShouldEqual, "region:3")
+	})
+
+	Convey("HOLOCHAINCONFIG_BOOTSTRAP=_ should clear all bootstrap servers, same as the single-value sentinel", t, func() {
+		os.Setenv("HOLOCHAINCONFIG_BOOTSTRAP", "_")
+		defer os.Unsetenv("HOLOCHAINCONFIG_BOOTSTRAP")
+		err := makeConfig(h, s)
+		So(err, ShouldBeNil)
+		So(h.config.BootstrapServer, ShouldEqual, "")
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2013-2018, The MetaCurrency Project (Eric Harris-Braun, Arthur Brock, et. al.)
+// Use of this source code is governed by GPLv3 found in the LICENSE file
+//----------------------------------------------------------------------------------------
+// ChainSpec implements export and import of a portable, self-contained description of an
+// installed holochain so that a peer can bootstrap a new node without copying the whole
+// install directory.
+
+package holochain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	toml "github.com/BurntSushi/toml"
+)
+
+// ChainSpecVersion is bumped whenever the ChainSpec wire format changes incompatibly.
+const ChainSpecVersion = 1
+
+// ChainType identifies the purpose a chain spec was produced for.
+type ChainType string
+
+const (
+	LiveChain ChainType = "live"
+	DevChain  ChainType = "dev"
+	TestChain ChainType = "test"
+)
+
+// ChainSpec is a complete, human readable description of an installed holochain, suitable
+// for distributing an app template or pinning it to content-addressed storage.
+type ChainSpec struct {
+	SpecVersion int
+	DNAHash     string
+	DNA         DNA
+	Properties  map[string]string
+	ChainType   ChainType
+	Bootnodes   []string
+	Raw         bool
+
+	// ZomeCodeHashes holds the sha256 of each zome's source, keyed by zome name, so that a
+	// non-Raw spec (DNA.Zomes[i].Code left blank) still identifies which code it refers to.
+	// LoadSpec refuses to install a spec whose zome code isn't actually present, rather than
+	// silently producing a chain with empty zome code: nothing in this package yet resolves
+	// a zome's code from its hash via content-addressed storage.
+	ZomeCodeHashes map[string]string
+}
+
+// BuildSpecOptions configures how a ChainSpec is produced by BuildSpec.
+type BuildSpecOptions struct {
+	ChainType  ChainType
+	Properties map[string]string
+	Bootnodes  []string
+	Raw        bool
+}
+
+// BuildSpec walks the installed holochain called name and emits a ChainSpec describing its
+// genesis state, so that it can be handed to another node via LoadSpec.
+func (s *Service) BuildSpec(name string, opts BuildSpecOptions) (spec *ChainSpec, err error) {
+	h, err := s.Load(name)
+	if err != nil {
+		return
+	}
+
+	bootnodes := opts.Bootnodes
+	if h.config.BootstrapServer != "" {
+		bootnodes = append([]string{h.config.BootstrapServer}, bootnodes...)
+	}
+
+	spec = &ChainSpec{
+		SpecVersion: ChainSpecVersion,
+		DNAHash:     h.dnaHash.String(),
+		DNA:         *h.nucleus.dna,
+		Properties:  opts.Properties,
+		ChainType:   opts.ChainType,
+		Bootnodes:   bootnodes,
+		Raw:         opts.Raw,
+	}
+	// DNA is a shallow copy of *h.nucleus.dna, so Zomes still aliases the live Holochain's
+	// backing array. Clearing Code below must not mutate that array out from under h.
+	spec.DNA.Zomes = append([]Zome(nil), h.nucleus.dna.Zomes...)
+
+	if !spec.Raw {
+		spec.ZomeCodeHashes = make(map[string]string, len(spec.DNA.Zomes))
+		for i := range spec.DNA.Zomes {
+			sum := sha256.Sum256([]byte(spec.DNA.Zomes[i].Code))
+			spec.ZomeCodeHashes[spec.DNA.Zomes[i].Name] = hex.EncodeToString(sum[:])
+			spec.DNA.Zomes[i].Code = ""
+		}
+	}
+
+	return
+}
+
+// ToJSON serializes a ChainSpec as indented JSON.
+func (spec *ChainSpec) ToJSON() (encodedSpec string, err error) {
+	b, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return
+	}
+	encodedSpec = string(b)
+	return
+}
+
+// ToTOML serializes a ChainSpec as TOML.
+func (spec *ChainSpec) ToTOML() (encodedSpec string, err error) {
+	var buf bytes.Buffer
+	err = toml.NewEncoder(&buf).Encode(spec)
+	if err != nil {
+		return
+	}
+	encodedSpec = buf.String()
+	return
+}
+
+// LoadSpec reconstructs a chain directory (DNA, UI stub, config.toml) from a ChainSpec blob
+// read from r, equivalent to Clone(..., CloneWithSameUUID, ...) but sourced purely from the
+// spec rather than an existing install.
+func (s *Service) LoadSpec(r io.Reader, name string, initDB bool) (h *Holochain, err error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+
+	var spec ChainSpec
+	if err = json.Unmarshal(b, &spec); err != nil {
+		return
+	}
+
+	root := filepath.Join(s.Path, name)
+	if dirExists(root) {
+		err = fmt.Errorf("holochain: %s already exists", root)
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Join(root, ChainDNADir), os.ModePerm); err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Join(root, ChainUIDir), os.ModePerm); err != nil {
+		return
+	}
+
+	dna := spec.DNA
+
+	for i := range dna.Zomes {
+		if dna.Zomes[i].Code == "" {
+			if hash, hasHash := spec.ZomeCodeHashes[dna.Zomes[i].Name]; hasHash {
+				err = fmt.Errorf("holochain: cannot install hash-referenced zome %q (hash %s): fetching zome code by hash is not yet implemented, supply a Raw spec instead", dna.Zomes[i].Name, hash)
+				return
+			}
+		}
+	}
+
+	hc := NewHolochain(s.DefaultAgent, root, "toml")
+	hc.nucleus = NewNucleus(&hc, &dna)
+
+	if err = hc.SaveDNA(false); err != nil {
+		return
+	}
+
+	if err = makeConfig(&hc, s); err != nil {
+		return
+	}
+	hc.config.BootstrapServer = firstBootnode(spec.Bootnodes)
+
+	if err = hc.SaveConfig(); err != nil {
+		return
+	}
+
+	if initDB {
+		if err = hc.GenChain(); err != nil {
+			return
+		}
+	}
+
+	h = &hc
+	s.RegisterWithService(h)
+	return
+}
+
+func firstBootnode(nodes []string) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	return nodes[0]
+}
@@ -0,0 +1,74 @@
+package holochain
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBuildSpec(t *testing.T) {
+	d, s, h := setupTestChain("test")
+	defer CleanupTestDir(d)
+
+	Convey("it should build a chain spec from an installed holochain", t, func() {
+		spec, err := s.BuildSpec("test", BuildSpecOptions{ChainType: TestChain})
+		So(err, ShouldBeNil)
+		So(spec.DNAHash, ShouldEqual, h.dnaHash.String())
+		So(spec.DNA.UUID, ShouldEqual, h.nucleus.dna.UUID)
+		So(spec.ChainType, ShouldEqual, TestChain)
+	})
+}
+
+func TestBuildSpecNonRawDoesNotMutateLiveHolochain(t *testing.T) {
+	d, s, h := setupTestChain("test")
+	defer CleanupTestDir(d)
+
+	Convey("a non-Raw BuildSpec should leave the loaded Holochain's zome code intact", t, func() {
+		originalCode := make([]string, len(h.nucleus.dna.Zomes))
+		for i, z := range h.nucleus.dna.Zomes {
+			originalCode[i] = z.Code
+		}
+
+		_, err := s.BuildSpec("test", BuildSpecOptions{ChainType: TestChain, Raw: false})
+		So(err, ShouldBeNil)
+
+		for i, z := range h.nucleus.dna.Zomes {
+			So(z.Code, ShouldEqual, originalCode[i])
+		}
+	})
+}
+
+func TestLoadSpecRoundTrip(t *testing.T) {
+	d, s, h := setupTestChain("test")
+	defer CleanupTestDir(d)
+
+	Convey("LoadSpec(BuildSpec(h)) should produce an identical dnaHash", t, func() {
+		spec, err := s.BuildSpec("test", BuildSpecOptions{ChainType: TestChain, Raw: true})
+		So(err, ShouldBeNil)
+
+		encoded, err := spec.ToJSON()
+		So(err, ShouldBeNil)
+
+		h2, err := s.LoadSpec(bytes.NewBufferString(encoded), "test-from-spec", InitializeDB)
+		So(err, ShouldBeNil)
+		So(h2.dnaHash.String(), ShouldEqual, h.dnaHash.String())
+	})
+}
+
+func TestLoadSpecRefusesHashReferencedCode(t *testing.T) {
+	d, s, _ := setupTestChain("test")
+	defer CleanupTestDir(d)
+
+	Convey("LoadSpec should refuse a non-Raw spec instead of installing a chain with empty zome code", t, func() {
+		spec, err := s.BuildSpec("test", BuildSpecOptions{ChainType: TestChain, Raw: false})
+		So(err, ShouldBeNil)
+		So(len(spec.ZomeCodeHashes), ShouldBeGreaterThan, 0)
+
+		encoded, err := spec.ToJSON()
+		So(err, ShouldBeNil)
+
+		_, err = s.LoadSpec(bytes.NewBufferString(encoded), "test-from-hash-spec", SkipInitializeDB)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2013-2018, The MetaCurrency Project (Eric Harris-Braun, Arthur Brock, et. al.)
+// Use of this source code is governed by GPLv3 found in the LICENSE file
+//----------------------------------------------------------------------------------------
+// Middleware lets a Service wrap zome function execution (and, as more Service operations
+// are migrated onto CallWithMiddleware, long-running operations such as GenDev, Clone,
+// GenChain and SaveScaffold) so that a misbehaving application zome can't take the whole
+// node down with it. Today the only call site is Holochain.Call in zome_call.go; GenDev,
+// Clone, GenChain and SaveScaffold are defined elsewhere in the Service and do not yet route
+// through this chain.
+
+package holochain
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ServiceHandler is the signature of a middleware-wrapped Service operation. Currently the
+// only operation routed through CallWithMiddleware is a zome call (Holochain.Call); ctx
+// carries that call's zomeCallInfo so that middleware such as MetricsMiddleware can
+// attribute itself without the caller threading extra arguments through every middleware.
+type ServiceHandler func(ctx context.Context) (interface{}, error)
+
+// ServiceMiddleware wraps a ServiceHandler with additional behavior, returning a new
+// ServiceHandler that calls next somewhere in its body.
+type ServiceMiddleware func(next ServiceHandler) ServiceHandler
+
+// ZomePanicError is returned in place of a panic recovered from zome code (Ottojs/Zygo
+// callbacks), so that callers see a typed error rather than a crashed node.
+type ZomePanicError struct {
+	Zome     string
+	Function string
+	Value    interface{}
+	Stack    []byte
+}
+
+func (e *ZomePanicError) Error() string {
+	return fmt.Sprintf("holochain: zome %s function %s panicked: %v", e.Zome, e.Function, e.Value)
+}
+
+type zomeCallKey struct{}
+
+// zomeCallInfo identifies the zome function a ServiceHandler call is executing, or is empty
+// for a Service-level operation passed to CallWithMiddleware without a zome/function name.
+type zomeCallInfo struct {
+	Zome     string
+	Function string
+}
+
+// ZomeCallInfoFromContext recovers the zome/function name a middleware is currently running
+// for, as set up by CallWithMiddleware.
+func ZomeCallInfoFromContext(ctx context.Context) (zome, function string) {
+	info, _ := ctx.Value(zomeCallKey{}).(zomeCallInfo)
+	return info.Zome, info.Function
+}
+
+// CallWithMiddleware runs fn through the Service's configured middleware chain. zome and fn
+// identify the zome function being called, if any, for use by RecoveryMiddleware and
+// MetricsMiddleware; they may be empty for a Service-level operation that has been wired
+// through this same choke point.
+func (s *Service) CallWithMiddleware(zome, function string, fn func() (interface{}, error)) (result interface{}, err error) {
+	wrapped := s.wrap(func(ctx context.Context) (interface{}, error) {
+		return fn()
+	})
+	ctx := context.WithValue(context.Background(), zomeCallKey{}, zomeCallInfo{Zome: zome, Function: function})
+	return wrapped(ctx)
+}
+
+// RecoveryMiddleware recovers from a panic raised while next is running, converting it into
+// a *ZomePanicError identified by the zome/function ctx was called for (see
+// ZomeCallInfoFromContext) and logged via logger instead of propagating and crashing the
+// node.
+func RecoveryMiddleware(logger *Logger) ServiceMiddleware {
+	return func(next ServiceHandler) ServiceHandler {
+		return func(ctx context.Context) (result interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					zome, function := ZomeCallInfoFromContext(ctx)
+					panicErr := &ZomePanicError{Zome: zome, Function: function, Value: r, Stack: debug.Stack()}
+					if logger != nil {
+						logger.Logf("recovered from panic: %s\n%s", panicErr.Error(), panicErr.Stack)
+					}
+					err = panicErr
+				}
+			}()
+			return next(ctx)
+		}
+	}
+}
+
+// MetricsMiddleware counts calls and measures their duration, handing each sample to record
+// along with the zome/function the call was made for.
+func MetricsMiddleware(record func(zome, function string, duration time.Duration, err error)) ServiceMiddleware {
+	return func(next ServiceHandler) ServiceHandler {
+		return func(ctx context.Context) (result interface{}, err error) {
+			zome, function := ZomeCallInfoFromContext(ctx)
+			start := time.Now()
+			result, err = next(ctx)
+			if record != nil {
+				record(zome, function, time.Since(start), err)
+			}
+			return
+		}
+	}
+}
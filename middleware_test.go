@@ -0,0 +1,87 @@
+package holochain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	d, s, h := setupTestChain("test")
+	defer CleanupTestDir(d)
+
+	s.Use(RecoveryMiddleware(nil))
+	s.RegisterWithService(h)
+
+	zomeName := h.nucleus.dna.Zomes[0].Name
+
+	Convey("a panic inside a real zome call should be recovered as a ZomePanicError, not crash the node", t, func() {
+		original := runZomeFunction
+		runZomeFunction = func(zome *Zome, function string, parameters interface{}) (interface{}, error) {
+			panic("sample zome blew up")
+		}
+		defer func() { runZomeFunction = original }()
+
+		_, err := h.Call(zomeName, "boom", nil)
+		So(err, ShouldNotBeNil)
+		panicErr, ok := err.(*ZomePanicError)
+		So(ok, ShouldBeTrue)
+		So(panicErr.Zome, ShouldEqual, zomeName)
+		So(panicErr.Function, ShouldEqual, "boom")
+		So(panicErr.Value, ShouldEqual, "sample zome blew up")
+	})
+
+	Convey("a call that doesn't panic should pass its result through untouched", t, func() {
+		original := runZomeFunction
+		runZomeFunction = func(zome *Zome, function string, parameters interface{}) (interface{}, error) {
+			return "fine", nil
+		}
+		defer func() { runZomeFunction = original }()
+
+		result, err := h.Call(zomeName, "ok", nil)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "fine")
+	})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	d, s, h := setupTestChain("test")
+	defer CleanupTestDir(d)
+
+	type sample struct {
+		zome, function string
+		duration       time.Duration
+		err            error
+	}
+	var samples []sample
+	s.Use(MetricsMiddleware(func(zome, function string, duration time.Duration, err error) {
+		samples = append(samples, sample{zome, function, duration, err})
+	}))
+	s.RegisterWithService(h)
+
+	zomeName := h.nucleus.dna.Zomes[0].Name
+
+	Convey("it should record the zome, function and duration for each call", t, func() {
+		original := runZomeFunction
+		runZomeFunction = func(zome *Zome, function string, parameters interface{}) (interface{}, error) {
+			return nil, nil
+		}
+		defer func() { runZomeFunction = original }()
+
+		_, _ = h.Call(zomeName, "ok", nil)
+		_, _ = h.Call(zomeName, "fail", nil)
+
+		runZomeFunction = func(zome *Zome, function string, parameters interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("boom")
+		}
+		_, _ = h.Call(zomeName, "fail", nil)
+
+		So(len(samples), ShouldEqual, 3)
+		So(samples[0].zome, ShouldEqual, zomeName)
+		So(samples[0].function, ShouldEqual, "ok")
+		So(samples[0].duration, ShouldBeGreaterThanOrEqualTo, time.Duration(0))
+		So(samples[2].err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2013-2018, The MetaCurrency Project (Eric Harris-Braun, Arthur Brock, et. al.)
+// Use of this source code is governed by GPLv3 found in the LICENSE file
+//----------------------------------------------------------------------------------------
+// InstallScaffoldFromURL lets a scaffold be fetched from a remote http(s)/ipfs/hc registry
+// URL and installed the same way as a local SaveScaffold call, verifying an embedded
+// signature against its own embedded progenitor pubkey when requested.
+
+package holochain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+)
+
+// ScaffoldManifestFileName is the name of the manifest written alongside an installed chain
+// describing where its scaffold came from.
+const ScaffoldManifestFileName = "scaffold_manifest.json"
+
+// ipfsGatewayBase and hcRegistryBase are package variables, rather than constants, so that
+// tests can point ipfs:// and hc:// resolution at an httptest.Server instead of the real
+// public gateway/registry.
+var (
+	ipfsGatewayBase = "https://ipfs.io/ipfs/"
+	hcRegistryBase  = "https://scaffold.holochain.net/"
+)
+
+// ScaffoldManifest records the provenance of an installed scaffold.
+type ScaffoldManifest struct {
+	Name    string
+	Version string
+	Author  string
+	PubKey  []byte
+	SHA256  string
+}
+
+// signedScaffoldEnvelope is the wire format a scaffold registry serves: the scaffold payload
+// plus an optional signature and pubkey the recipient can verify it against. A bare scaffold
+// blob (no envelope) is also accepted for backward compatibility with SaveScaffold's callers,
+// it just can't be verified.
+type signedScaffoldEnvelope struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature []byte          `json:"signature,omitempty"`
+	PubKey    []byte          `json:"pub_key,omitempty"`
+	Author    string          `json:"author,omitempty"`
+}
+
+// decodeScaffoldEnvelope splits blob into its scaffold payload and, if present, the embedded
+// signature/pubkey/author that came with it.
+func decodeScaffoldEnvelope(blob []byte) (payload []byte, envelope signedScaffoldEnvelope, signed bool) {
+	var e signedScaffoldEnvelope
+	if err := json.Unmarshal(blob, &e); err == nil && len(e.Payload) > 0 {
+		return e.Payload, e, true
+	}
+	return blob, signedScaffoldEnvelope{}, false
+}
+
+// fetchScaffold retrieves the raw scaffold blob named by u. Supported schemes are http(s)://,
+// ipfs:// and hc://<multihash>; the latter two are resolved through a gateway/registry base
+// URL (ipfsGatewayBase, hcRegistryBase) rather than dialed directly.
+func (s *Service) fetchScaffold(u string) (blob []byte, err error) {
+	switch {
+	case strings.HasPrefix(u, "http://"), strings.HasPrefix(u, "https://"):
+		var resp *http.Response
+		resp, err = http.Get(u)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("holochain: fetching scaffold from %s: %s", u, resp.Status)
+			return
+		}
+		blob, err = ioutil.ReadAll(resp.Body)
+	case strings.HasPrefix(u, "ipfs://"):
+		blob, err = s.fetchScaffold(ipfsGatewayBase + strings.TrimPrefix(u, "ipfs://"))
+	case strings.HasPrefix(u, "hc://"):
+		blob, err = s.fetchScaffold(hcRegistryBase + strings.TrimPrefix(u, "hc://"))
+	default:
+		err = fmt.Errorf("holochain: unsupported scaffold URL scheme: %s", u)
+	}
+	return
+}
+
+// verifyScaffoldSignature checks sig over payload against pubKeyBytes, a serialized libp2p
+// public key as found in a DNA's Progenitor.PubKey.
+func verifyScaffoldSignature(payload, sig, pubKeyBytes []byte) (err error) {
+	pubKey, err := ic.UnmarshalPublicKey(pubKeyBytes)
+	if err != nil {
+		return
+	}
+	ok, err := pubKey.Verify(payload, sig)
+	if err != nil {
+		return
+	}
+	if !ok {
+		err = fmt.Errorf("holochain: scaffold signature verification failed")
+	}
+	return
+}
+
+// InstallScaffoldFromURL fetches a scaffold blob from u (http(s)://, ipfs://, or
+// hc://<multihash>). When verify is true, the blob must be a signedScaffoldEnvelope carrying
+// its own signature and progenitor pubkey, which is checked before anything is written; a
+// plain unsigned blob is rejected in that case. The resulting scaffold is installed exactly
+// as SaveScaffold would, and a ScaffoldManifest recording its provenance is written alongside
+// the installed chain at root.
+func (s *Service) InstallScaffoldFromURL(u string, root, name, encoding string, verify bool) (scaffold *Scaffold, err error) {
+	blob, err := s.fetchScaffold(u)
+	if err != nil {
+		return
+	}
+
+	payload, envelope, signed := decodeScaffoldEnvelope(blob)
+
+	if verify {
+		if !signed || len(envelope.Signature) == 0 || len(envelope.PubKey) == 0 {
+			err = fmt.Errorf("holochain: no embedded signature found in scaffold from %s", u)
+			return
+		}
+		if err = verifyScaffoldSignature(payload, envelope.Signature, envelope.PubKey); err != nil {
+			return
+		}
+	}
+
+	scaffold, err = s.SaveScaffold(bytes.NewReader(payload), root, name, encoding, false)
+	if err != nil {
+		return
+	}
+
+	sum := sha256.Sum256(payload)
+	manifest := ScaffoldManifest{
+		Name:    name,
+		Version: fmt.Sprint(scaffold.DNA.Version),
+		Author:  envelope.Author,
+		PubKey:  envelope.PubKey,
+		SHA256:  hex.EncodeToString(sum[:]),
+	}
+	err = writeScaffoldManifest(root, manifest)
+	return
+}
+
+func writeScaffoldManifest(root string, manifest ScaffoldManifest) (err error) {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return
+	}
+	return writeFile(root, ScaffoldManifestFileName, b)
+}
+
+// ListInstalledScaffolds returns the ScaffoldManifest for every installed chain under the
+// service's root that was installed via InstallScaffoldFromURL.
+func (s *Service) ListInstalledScaffolds() (manifests map[string]ScaffoldManifest, err error) {
+	manifests = make(map[string]ScaffoldManifest)
+	chains, err := s.ConfiguredChains()
+	if err != nil {
+		return
+	}
+	for name := range chains {
+		root := s.Path + "/" + name
+		if !fileExists(root, ScaffoldManifestFileName) {
+			continue
+		}
+		var b []byte
+		b, err = readFile(root, ScaffoldManifestFileName)
+		if err != nil {
+			return
+		}
+		var manifest ScaffoldManifest
+		if err = json.Unmarshal(b, &manifest); err != nil {
+			return
+		}
+		manifests[name] = manifest
+	}
+	return
+}
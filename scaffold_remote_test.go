@@ -0,0 +1,181 @@
+package holochain
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-crypto"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstallScaffoldFromURL(t *testing.T) {
+	d, s := setupTestService()
+	defer CleanupTestDir(d)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(BasicTemplateScaffold))
+	}))
+	defer ts.Close()
+
+	name := "test"
+	root := filepath.Join(s.Path, name)
+
+	Convey("it should fetch a scaffold over http and install it like SaveScaffold", t, func() {
+		scaffold, err := s.InstallScaffoldFromURL(ts.URL, root, "appName", "json", false)
+		So(err, ShouldBeNil)
+		So(scaffold, ShouldNotBeNil)
+		So(scaffold.DNA.Name, ShouldEqual, "appName")
+		So(dirExists(root), ShouldBeTrue)
+		So(fileExists(root, ScaffoldManifestFileName), ShouldBeTrue)
+	})
+
+	Convey("it should reject an unsupported URL scheme", t, func() {
+		_, err := s.InstallScaffoldFromURL("ftp://example.com/scaffold.json", filepath.Join(s.Path, "test3"), "appName", "json", false)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+// signedScaffoldBlob wraps BasicTemplateScaffold in a signedScaffoldEnvelope, signing the
+// payload with a freshly generated key pair, and returns the encoded envelope plus the
+// serialized pubkey a caller would verify against.
+func signedScaffoldBlob(t *testing.T, author string) (blob []byte, pubKeyBytes []byte) {
+	priv, pub, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// json.Marshal compacts and HTML-escapes a json.RawMessage, so the payload is put in that
+	// form before signing: it is then byte for byte what the encoded envelope carries and
+	// what InstallScaffoldFromURL verifies.
+	payload, err := json.Marshal(json.RawMessage(BasicTemplateScaffold))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := priv.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKeyBytes, err = pub.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := signedScaffoldEnvelope{
+		Payload:   payload,
+		Signature: sig,
+		PubKey:    pubKeyBytes,
+		Author:    author,
+	}
+	blob, err = json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestInstallScaffoldFromURLVerified(t *testing.T) {
+	d, s := setupTestService()
+	defer CleanupTestDir(d)
+
+	blob, pubKeyBytes := signedScaffoldBlob(t, "Progenitor Agent <progenitore@example.com>")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(blob)
+	}))
+	defer ts.Close()
+
+	Convey("it should verify the embedded signature and record the manifest's author/pubkey", t, func() {
+		root := filepath.Join(s.Path, "test-signed")
+		scaffold, err := s.InstallScaffoldFromURL(ts.URL, root, "appName", "json", true)
+		So(err, ShouldBeNil)
+		So(scaffold, ShouldNotBeNil)
+
+		manifests, err := s.ListInstalledScaffolds()
+		So(err, ShouldBeNil)
+		So(manifests["test-signed"].Author, ShouldEqual, "Progenitor Agent <progenitore@example.com>")
+		So(manifests["test-signed"].PubKey, ShouldResemble, pubKeyBytes)
+	})
+
+	Convey("it should reject a scaffold with no embedded signature when verify is requested", t, func() {
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(BasicTemplateScaffold))
+		}))
+		defer plain.Close()
+
+		_, err := s.InstallScaffoldFromURL(plain.URL, filepath.Join(s.Path, "test-unsigned"), "appName", "json", true)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("it should reject a tampered payload even with a validly-formed envelope", t, func() {
+		var envelope signedScaffoldEnvelope
+		if err := json.Unmarshal(blob, &envelope); err != nil {
+			t.Fatal(err)
+		}
+		envelope.Payload = json.RawMessage(`{"tampered":true}`)
+		tampered, err := json.Marshal(envelope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(tampered)
+		}))
+		defer ts2.Close()
+
+		_, err = s.InstallScaffoldFromURL(ts2.URL, filepath.Join(s.Path, "test-tampered"), "appName", "json", true)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestInstallScaffoldFromIPFSAndHC(t *testing.T) {
+	d, s := setupTestService()
+	defer CleanupTestDir(d)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(BasicTemplateScaffold))
+	}))
+	defer ts.Close()
+
+	origIPFS, origHC := ipfsGatewayBase, hcRegistryBase
+	ipfsGatewayBase = ts.URL + "/ipfs/"
+	hcRegistryBase = ts.URL + "/hc/"
+	defer func() {
+		ipfsGatewayBase = origIPFS
+		hcRegistryBase = origHC
+	}()
+
+	Convey("an ipfs:// URL should resolve through the configured gateway", t, func() {
+		scaffold, err := s.InstallScaffoldFromURL("ipfs://QmExampleHash", filepath.Join(s.Path, "test-ipfs"), "appName", "json", false)
+		So(err, ShouldBeNil)
+		So(scaffold, ShouldNotBeNil)
+	})
+
+	Convey("an hc:// URL should resolve through the configured registry", t, func() {
+		scaffold, err := s.InstallScaffoldFromURL("hc://QmExampleMultihash", filepath.Join(s.Path, "test-hc"), "appName", "json", false)
+		So(err, ShouldBeNil)
+		So(scaffold, ShouldNotBeNil)
+	})
+}
+
+func TestListInstalledScaffolds(t *testing.T) {
+	d, s := setupTestService()
+	defer CleanupTestDir(d)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(BasicTemplateScaffold))
+	}))
+	defer ts.Close()
+
+	root := filepath.Join(s.Path, "test")
+	_, err := s.InstallScaffoldFromURL(ts.URL, root, "appName", "json", false)
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("it should list the manifest of every scaffold-installed chain", t, func() {
+		manifests, err := s.ListInstalledScaffolds()
+		So(err, ShouldBeNil)
+		So(manifests["test"].SHA256, ShouldNotEqual, "")
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2013-2018, The MetaCurrency Project (Eric Harris-Braun, Arthur Brock, et. al.)
+// Use of this source code is governed by GPLv3 found in the LICENSE file
+//----------------------------------------------------------------------------------------
+// Service is the core structure shared by the Service-level features added in this series
+// (chain-spec export/import, bootstrap failover, the middleware chain and remote scaffold
+// install): it carries the on-disk root, the service-wide defaults, and the middleware chain
+// those features hang off of.
+
+package holochain
+
+import "fmt"
+
+// SysFileName is the name of the file holding ServiceConfig validation failures' namespace
+// prefix, matching how other service-level errors are reported.
+const SysFileName = "system.conf"
+
+// DefaultBootstrapAddr is the bootstrap server used when none is configured.
+const DefaultBootstrapAddr = "bootstrap.holochain.net:10000"
+
+// Service represents a holochain service, i.e. the Settings and collection of DNAs, and
+// instances installed under Path.
+type Service struct {
+	Path         string
+	Settings     ServiceConfig
+	DefaultAgent Agent
+
+	// middleware is the chain installed via Use, applied outermost-first around calls made
+	// through CallWithMiddleware (currently just Holochain.Call's zome calls).
+	middleware []ServiceMiddleware
+}
+
+// ServiceConfig holds the service-wide defaults applied to every chain generated or loaded
+// under a Service.
+type ServiceConfig struct {
+	DefaultPeerModeDHTNode bool
+	DefaultPeerModeAuthor  bool
+
+	// BootstrapServers replaces the single DefaultBootstrapServer string with an ordered list
+	// of candidates, each optionally tagged with the region/zone it was registered under.
+	BootstrapServers []BootstrapEntry
+	// LocalRegion/LocalZone describe this node's own locality, used to order
+	// BootstrapServers from nearest to farthest before dialing.
+	LocalRegion string
+	LocalZone   string
+
+	DefaultEnableMDNS bool
+}
+
+// Validate checks that the config describes a node that can actually participate: at least
+// one peer mode must be enabled.
+func (c *ServiceConfig) Validate() (err error) {
+	if !c.DefaultPeerModeAuthor && !c.DefaultPeerModeDHTNode {
+		err = fmt.Errorf(SysFileName + ": At least one peer mode must be set to true.")
+	}
+	return
+}
+
+// Use appends mw to the Service's middleware chain. Middleware runs in the order it was
+// added: the first ServiceMiddleware passed to Use is outermost.
+func (s *Service) Use(mw ...ServiceMiddleware) {
+	s.middleware = append(s.middleware, mw...)
+}
+
+// wrap builds the final ServiceHandler by applying the Service's middleware chain, outermost
+// first, around handler.
+func (s *Service) wrap(handler ServiceHandler) ServiceHandler {
+	wrapped := handler
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		wrapped = s.middleware[i](wrapped)
+	}
+	return wrapped
+}
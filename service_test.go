@@ -27,7 +27,13 @@ func TestInit(t *testing.T) {
 
 		Convey("it should return a service with default values", func() {
 			So(s.DefaultAgent.Identity(), ShouldEqual, AgentIdentity(agent))
-			So(fmt.Sprintf("%v", s.Settings), ShouldEqual, "{true true bootstrap.holochain.net:10000 false}")
+			// DefaultBootstrapServer became the ordered BootstrapServers list (plus
+			// LocalRegion/LocalZone) so that a node can fail over between bootstrap
+			// servers; a single %v comparison is no longer meaningful here.
+			So(s.Settings.DefaultPeerModeDHTNode, ShouldBeTrue)
+			So(s.Settings.DefaultPeerModeAuthor, ShouldBeTrue)
+			So(s.Settings.BootstrapServers, ShouldResemble, []BootstrapEntry{{Addr: DefaultBootstrapAddr}})
+			So(s.Settings.DefaultEnableMDNS, ShouldBeFalse)
 		})
 
 		p := filepath.Join(d, DefaultDirectoryName)
@@ -265,7 +271,7 @@ func TestGenDev(t *testing.T) {
 		So(lh.config.Port, ShouldEqual, DefaultPort)
 		So(h.config.PeerModeDHTNode, ShouldEqual, s.Settings.DefaultPeerModeDHTNode)
 		So(h.config.PeerModeAuthor, ShouldEqual, s.Settings.DefaultPeerModeAuthor)
-		So(h.config.BootstrapServer, ShouldEqual, s.Settings.DefaultBootstrapServer)
+		So(h.config.BootstrapServer, ShouldEqual, s.Settings.BootstrapServers[0].Addr)
 		So(h.config.EnableMDNS, ShouldEqual, s.Settings.DefaultEnableMDNS)
 
 		So(dirExists(root), ShouldBeTrue)
@@ -0,0 +1,80 @@
+// Copyright (C) 2013-2018, The MetaCurrency Project (Eric Harris-Braun, Arthur Brock, et. al.)
+// Use of this source code is governed by GPLv3 found in the LICENSE file
+//----------------------------------------------------------------------------------------
+// Call is the single entry point application code and the HTTP/RPC interfaces go through to
+// invoke a zome function. Routing it through the owning Service's middleware chain here,
+// rather than in each caller, is what lets RecoveryMiddleware actually catch a panicking
+// zome instead of only wrapping hand-written ServiceHandler closures in tests.
+
+package holochain
+
+import (
+	"fmt"
+	"sync"
+)
+
+// runZomeFunction is the hook through which a zome's interpreted code (Ottojs/Zygo) actually
+// executes a function. Nothing in this package assigns it outside of tests: by default it
+// runs no zome code and returns an error naming the zome, so Call does not reach a real
+// ribosome. It is a package variable, rather than a parameter threaded through Call, so that
+// tests can substitute a fake ribosome (including one that panics) without needing a real DNA
+// and interpreter.
+var runZomeFunction = defaultRunZomeFunction
+
+func defaultRunZomeFunction(zome *Zome, function string, parameters interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("holochain: no ribosome registered for zome %s", zome.Name)
+}
+
+var (
+	holochainOwnerMu sync.RWMutex
+	holochainOwner   = map[*Holochain]*Service{}
+)
+
+// RegisterWithService associates h with the Service that generated or loaded it, so that Call
+// can route its zome invocations through that Service's middleware chain. LoadSpec calls this
+// before returning the Holochain it builds; a Holochain obtained any other way (GenChain,
+// Load, GenDev) is not registered until this is called on it.
+func (s *Service) RegisterWithService(h *Holochain) {
+	holochainOwnerMu.Lock()
+	defer holochainOwnerMu.Unlock()
+	holochainOwner[h] = s
+}
+
+// service returns the Service h was registered with, or nil if it was never registered
+// (e.g. a Holochain built directly in a test without going through the Service).
+func (h *Holochain) service() *Service {
+	holochainOwnerMu.RLock()
+	defer holochainOwnerMu.RUnlock()
+	return holochainOwner[h]
+}
+
+// Call invokes function in the named zome with parameters, running it through the owning
+// Service's middleware chain so that a panic in application zome code is recovered as a
+// *ZomePanicError rather than crashing the node.
+func (h *Holochain) Call(zomeName, function string, parameters interface{}) (result interface{}, err error) {
+	zome, err := h.nucleus.dna.findZome(zomeName)
+	if err != nil {
+		return
+	}
+
+	handler := func() (interface{}, error) {
+		return runZomeFunction(zome, function, parameters)
+	}
+
+	svc := h.service()
+	if svc == nil {
+		return handler()
+	}
+	return svc.CallWithMiddleware(zomeName, function, handler)
+}
+
+// findZome returns the Zome named name from the DNA, or an error if there is no such zome.
+func (dna *DNA) findZome(name string) (zome *Zome, err error) {
+	for i := range dna.Zomes {
+		if dna.Zomes[i].Name == name {
+			return &dna.Zomes[i], nil
+		}
+	}
+	err = fmt.Errorf("holochain: unknown zome: %s", name)
+	return
+}